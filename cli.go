@@ -6,33 +6,92 @@
 package cli
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 )
 
 // A RunFunc is a function that invokes a command.
 type RunFunc func(args []string) error
 
+// A ContextRunFunc is an alternative to RunFunc for commands that need
+// access to parent flag state or the resolved command path, such as a leaf
+// command nested under Subcommands. A Command with RunCtx set is invoked
+// with RunCtx instead of Run.
+type ContextRunFunc func(ctx *Context) error
+
 // A Command represents an action that can be invoked or a help topic.
 type Command struct {
-	Run      RunFunc      // non-nil for an invokable action, otherwise a topic
-	Usage    string       // usage message
-	Short    string       // short (single-line) help text
-	Synopsis string       // multi-line help text
-	flags    flag.FlagSet // command line flags
+	Run      RunFunc        // non-nil for an invokable action, otherwise a topic
+	RunCtx   ContextRunFunc // alternative to Run; takes precedence if non-nil
+	Usage    string         // usage message
+	Short    string         // short (single-line) help text
+	Synopsis string         // multi-line help text
+	flags    flag.FlagSet   // command line flags
+
+	// Subcommands, if non-nil, makes this Command a command group: any
+	// arguments left over after parsing the command's own flags are
+	// dispatched to the named child command, enabling git/docker-style
+	// nesting such as "myprog remote add origin".
+	Subcommands *CommandSet
+
+	// Persistent, if true, cascades this command's flags into every
+	// command in Subcommands (and transitively their own Subcommands), so
+	// a flag like --verbose declared on a parent can also be given after
+	// the subcommand name, e.g. "myprog remote add --verbose".
+	Persistent bool
+
+	// ValidArgs lists the static set of completion candidates for this
+	// command's positional arguments, e.g. subcommand-like enum values.
+	ValidArgs []string
+
+	// ArgCompleter, if non-nil, supplies dynamic completion candidates
+	// (filenames, remote resources, etc.) for the argument currently being
+	// typed. prev holds the already-parsed positional arguments and cur
+	// holds the partial word to complete.
+	ArgCompleter ArgCompleter
+
+	name    string            // name this Command was registered under; used as a config key prefix
+	owner   *CommandSet       // CommandSet cmd was registered into, for name()/configKey path resolution
+	envVars map[string]string // flag name -> bound environment variable, for the *Env flag definers
+}
+
+// Register adds a named child command, creating a child CommandSet the
+// first time it's called. It is shorthand for initializing cmd.Subcommands
+// and registering with it directly.
+func (cmd *Command) Register(name string, child *Command) {
+	if cmd.Subcommands == nil {
+		cmd.Subcommands = NewCommandSet("", cmd.Short)
+		cmd.Subcommands.parent = cmd.owner
+		cmd.Subcommands.parentName = cmd.name
+	}
+	cmd.Subcommands.Register(name, child)
 }
 
 // A CommandSet represents a set of named commands.
 type CommandSet struct {
-	cmds map[string]*Command
-	Name string // program name as it should appear in usage; use name() accessor
-	Desc string // program description
+	cmds   map[string]*Command
+	Name   string // program name as it should appear in usage; use name() accessor
+	Desc   string // program description
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	config map[string]interface{} // parsed config file tree, set by SetConfigFile
+
+	// parent and parentName are set on the CommandSet Command.Register
+	// creates for cmd.Subcommands, so name() and path() can reconstruct
+	// the full command path (e.g. "myprog remote") even though such a
+	// CommandSet has no Name of its own.
+	parent     *CommandSet
+	parentName string
 }
 
 // NewCommandSet creates a new, empty command set.
@@ -44,51 +103,156 @@ func NewCommandSet(name, desc string) *CommandSet {
 	}
 }
 
+// SetOutput redirects usage and error output to stdout and stderr instead
+// of the process's os.Stdout and os.Stderr. A nil argument leaves the
+// corresponding stream unchanged; both start out as the process streams.
+// Embedding libraries and tests can use this to capture output instead of
+// writing directly to the process.
+func (cs *CommandSet) SetOutput(stdout, stderr io.Writer) {
+	if stdout != nil {
+		cs.stdout = stdout
+	}
+	if stderr != nil {
+		cs.stderr = stderr
+	}
+}
+
+func (cs *CommandSet) in() io.Reader {
+	if cs.stdin != nil {
+		return cs.stdin
+	}
+	return os.Stdin
+}
+
+func (cs *CommandSet) out() io.Writer {
+	if cs.stdout != nil {
+		return cs.stdout
+	}
+	return os.Stdout
+}
+
+func (cs *CommandSet) err() io.Writer {
+	if cs.stderr != nil {
+		return cs.stderr
+	}
+	return os.Stderr
+}
+
 // Register adds a named command. Register panics if cmd is nil.
 func (cs *CommandSet) Register(name string, cmd *Command) {
 	if cmd == nil {
 		panic("cli: nil command registered")
 	}
 	if _, ok := cs.cmds[name]; ok {
-		fmt.Fprintf(os.Stderr, "warning: command %q already exits", name)
+		fmt.Fprintf(cs.err(), "warning: command %q already exits", name)
 	}
 	if cmd.flags.Usage == nil {
 		cmd.flags.Usage = func() { cs.PrintUsage(name) }
 	}
+	cmd.name = name
+	cmd.owner = cs
 	cs.cmds[name] = cmd
 }
 
-// Run invokes a named command.
+// Run invokes a named command. A Context is built for it carrying
+// cs's stdin, stdout and stderr (see SetOutput) and a context.Context that's
+// cancelled on SIGINT or SIGTERM, so long-running RunCtx commands can
+// observe cancellation via ctx.Done(). Commands using the legacy RunFunc
+// signature are unaffected; cancellation is only observable through
+// RunCtx.
 func (cs *CommandSet) Run(name string, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return cs.run(ctx, name, args, nil, nil)
+}
+
+// run is the recursive implementation of Run. parent is the enclosing
+// Context when cs is a nested CommandSet reached via Command.Subcommands,
+// or nil at the top level. inherited holds the FlagSets of any ancestor
+// Commands marked Persistent, so their flags can also be given after a
+// subcommand name.
+func (cs *CommandSet) run(ctx context.Context, name string, args []string, parent *Context, inherited []*flag.FlagSet) error {
 	cmd, ok := cs.cmds[name]
 	if !ok {
 		switch {
 		case name != "help":
-			cs.unknownCommand(os.Stderr, name)
-		case len(args) == 1:
-			cs.PrintUsage(args[0])
+			cs.unknownCommand(name)
+		case len(args) >= 1:
+			cs.PrintUsagePath(args)
 		default:
 			cs.PrintUsage("")
 		}
 		os.Exit(2)
 	}
 
+	mergeFlags(&cmd.flags, inherited)
+
 	if err := cmd.flags.Parse(args); err != nil {
 		if err != flag.ErrHelp {
-			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(cs.err(), err)
+		}
+		os.Exit(2)
+	}
+
+	cs.resolveBindings(cmd)
+
+	c := newContext(ctx, parent, name, &cmd.flags)
+	if parent != nil {
+		c.Stdin, c.Stdout, c.Stderr = parent.Stdin, parent.Stdout, parent.Stderr
+	} else {
+		c.Stdin, c.Stdout, c.Stderr = cs.in(), cs.out(), cs.err()
+	}
+	rest := cmd.flags.Args()
+
+	if cmd.Subcommands != nil && len(rest) > 0 {
+		if cmd.Persistent {
+			inherited = append(inherited, &cmd.flags)
 		}
+		return cmd.Subcommands.run(ctx, rest[0], rest[1:], c, inherited)
+	}
+
+	// A command-group Command (Subcommands set, e.g. by Command.Register's
+	// shorthand) has no Run/RunCtx of its own, so invoking it bare, with no
+	// subcommand name to dispatch to, has nothing to call into. The same is
+	// true of a plain help topic (neither Subcommands nor Run/RunCtx set).
+	// Print its usage instead of calling a nil func.
+	if cmd.Run == nil && cmd.RunCtx == nil {
+		cs.PrintUsagePath([]string{name})
 		os.Exit(2)
 	}
 
-	return cmd.Run(cmd.flags.Args())
+	if cmd.RunCtx != nil {
+		c.args = rest
+		return cmd.RunCtx(c)
+	}
+	return cmd.Run(rest)
 }
 
-// name returns the program name as it should appear in a usage message.
-// e.g. name [<options>] <file>...
+// mergeFlags copies any flag from each FlagSet in srcs that dst doesn't
+// already define, sharing the same flag.Value so the two FlagSets read and
+// write the same underlying variable.
+func mergeFlags(dst *flag.FlagSet, srcs []*flag.FlagSet) {
+	for _, src := range srcs {
+		src.VisitAll(func(f *flag.Flag) {
+			if dst.Lookup(f.Name) == nil {
+				dst.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	}
+}
+
+// name returns the program name as it should appear in a usage message,
+// e.g. "name [<options>] <file>...". For a CommandSet nested under a
+// Command via Command.Register, this walks up to the root CommandSet and
+// prepends every ancestor command name in turn, e.g. "myprog remote" for
+// the CommandSet holding "myprog remote add".
 func (cs *CommandSet) name() string {
-	if cs.Name == "" {
+	if cs.Name != "" {
 		return cs.Name
 	}
+	if cs.parent != nil {
+		return cs.parent.name() + " " + cs.parentName
+	}
 	return path.Base(os.Args[0])
 }
 
@@ -97,6 +261,9 @@ func (cs *CommandSet) name() string {
 func (cs *CommandSet) actions() []string {
 	actionNames := make([]string, 0, len(cs.cmds))
 	for name := range cs.cmds {
+		if isHiddenCommand(name) {
+			continue
+		}
 		actionNames = append(actionNames, name)
 	}
 	sort.Strings(actionNames)
@@ -116,7 +283,8 @@ func (cs *CommandSet) partialMatch(prefix string) []string {
 	return names
 }
 
-func (cs *CommandSet) unknownCommand(w io.Writer, name string) {
+func (cs *CommandSet) unknownCommand(name string) {
+	w := cs.err()
 	fmt.Fprintf(w, "unknown command: %s\n", name)
 	if possibles := cs.partialMatch(name); len(possibles) > 0 {
 		fmt.Fprintln(w, "\nDid you mean one of these?")
@@ -126,6 +294,59 @@ func (cs *CommandSet) unknownCommand(w io.Writer, name string) {
 	}
 }
 
+// resolveBindings fills in flags defined with one of the *Env definers
+// that weren't given explicitly on the command line, in order: environment
+// variable, then config-file key, then the flag's existing default.
+func (cs *CommandSet) resolveBindings(cmd *Command) {
+	if len(cmd.envVars) == 0 {
+		return
+	}
+
+	explicit := make(map[string]bool, len(cmd.envVars))
+	cmd.flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, envVar := range cmd.envVars {
+		if explicit[name] {
+			continue
+		}
+		f := cmd.flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if envVar != "" {
+			if v, ok := os.LookupEnv(envVar); ok {
+				f.Value.Set(v)
+				continue
+			}
+		}
+		if v, ok := cs.configValue(cmd.configKey(name)); ok {
+			f.Value.Set(v)
+		}
+	}
+}
+
+// configKey returns the dotted config-file key for one of cmd's flags,
+// e.g. "export.timeout" for the "timeout" flag on the "export" command, or
+// "remote.add.timeout" for the "timeout" flag on "add" nested under
+// "remote", so leaf commands that share a name under different parents
+// (e.g. "remote add" and "branch add") don't collide on the same key.
+func (cmd *Command) configKey(flagName string) string {
+	if cmd.name == "" {
+		return ""
+	}
+	parts := append(cmd.owner.path(), cmd.name, flagName)
+	return strings.Join(parts, ".")
+}
+
+// path returns the command names leading to cs from the root CommandSet,
+// e.g. ["remote"] for the CommandSet holding "remote"'s Subcommands.
+func (cs *CommandSet) path() []string {
+	if cs.parent == nil {
+		return nil
+	}
+	return append(cs.parent.path(), cs.parentName)
+}
+
 // Bool defines a bool flag with specified name, default value, and usage
 // string. The return value is the address of a bool variable that stores
 // the value of the flag.
@@ -243,9 +464,91 @@ func (cmd *Command) Var(value flag.Value, name string, usage string) {
 	cmd.flags.Var(value, name, usage)
 }
 
+// bindEnv records envVar as the environment variable backing the flag
+// name, for resolution by CommandSet.resolveBindings. An empty envVar
+// disables the environment step, leaving config-file and default
+// resolution in effect.
+func (cmd *Command) bindEnv(name, envVar string) {
+	if cmd.envVars == nil {
+		cmd.envVars = make(map[string]string)
+	}
+	cmd.envVars[name] = envVar
+}
+
+// StringEnv defines a string flag like String, additionally resolving it
+// from envVar, or failing that a "<command>.<flag>" key in the config file
+// set with CommandSet.SetConfigFile, whenever it isn't given explicitly on
+// the command line.
+func (cmd *Command) StringEnv(name, value, envVar, usage string) *string {
+	p := cmd.flags.String(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// BoolEnv defines a bool flag like Bool, additionally resolving it from
+// envVar or the config file as StringEnv does.
+func (cmd *Command) BoolEnv(name string, value bool, envVar, usage string) *bool {
+	p := cmd.flags.Bool(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// DurationEnv defines a time.Duration flag like Duration, additionally
+// resolving it from envVar or the config file as StringEnv does.
+func (cmd *Command) DurationEnv(name string, value time.Duration, envVar, usage string) *time.Duration {
+	p := cmd.flags.Duration(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// IntEnv defines an int flag like Int, additionally resolving it from
+// envVar or the config file as StringEnv does.
+func (cmd *Command) IntEnv(name string, value int, envVar, usage string) *int {
+	p := cmd.flags.Int(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// Int64Env defines an int64 flag like Int64, additionally resolving it
+// from envVar or the config file as StringEnv does.
+func (cmd *Command) Int64Env(name string, value int64, envVar, usage string) *int64 {
+	p := cmd.flags.Int64(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// UintEnv defines a uint flag like Uint, additionally resolving it from
+// envVar or the config file as StringEnv does.
+func (cmd *Command) UintEnv(name string, value uint, envVar, usage string) *uint {
+	p := cmd.flags.Uint(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// Uint64Env defines a uint64 flag like Uint64, additionally resolving it
+// from envVar or the config file as StringEnv does.
+func (cmd *Command) Uint64Env(name string, value uint64, envVar, usage string) *uint64 {
+	p := cmd.flags.Uint64(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
+// Float64Env defines a float64 flag like Float64, additionally resolving
+// it from envVar or the config file as StringEnv does.
+func (cmd *Command) Float64Env(name string, value float64, envVar, usage string) *float64 {
+	p := cmd.flags.Float64(name, value, usage)
+	cmd.bindEnv(name, envVar)
+	return p
+}
+
 // Default is the default command set.
 var Default = NewCommandSet("", "")
 
+// helpFormat backs the hidden top-level --help-format flag: "text"
+// (default), "json", or "man". It controls the format Run uses when
+// printing the top-level command listing; PrintUsage ignores it.
+var helpFormat = flag.String("help-format", "text", "")
+
 // Register adds a named command and panics if cmd is nil.
 func Register(name string, cmd *Command) {
 	Default.Register(name, cmd)
@@ -254,12 +557,12 @@ func Register(name string, cmd *Command) {
 // Run parses the command-line flags from os.Args()[2:], and invokes the
 // subcommand named by os.Args()[1].
 func Run() error {
-	flag.Usage = func() { PrintUsage("") }
+	flag.Usage = func() { Default.PrintUsageFormat("", *helpFormat, os.Stderr) }
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) < 1 {
-		PrintUsage("")
+		flag.Usage()
 		os.Exit(2)
 	}
 
@@ -0,0 +1,89 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNestedUsagePathIncludesFullPrefix(t *testing.T) {
+	cs := NewCommandSet("myprog", "")
+	remote := &Command{Short: "manage remotes"}
+	cs.Register("remote", remote)
+	remote.Register("add", &Command{
+		Usage: "add <name> <url>",
+		Short: "add a remote",
+		Run:   func([]string) error { return nil },
+	})
+
+	var buf bytes.Buffer
+	remote.Subcommands.SetOutput(nil, &buf)
+	cs.PrintUsagePath([]string{"remote", "add"})
+
+	want := "usage: myprog remote add <name> <url>"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("usage output = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestNestedCommandDispatch(t *testing.T) {
+	cs := NewCommandSet("myprog", "")
+	remote := &Command{}
+	cs.Register("remote", remote)
+
+	var gotArgs []string
+	remote.Register("add", &Command{
+		Run: func(args []string) error {
+			gotArgs = args
+			return nil
+		},
+	})
+
+	if err := cs.Run("remote", []string{"add", "origin"}); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if want := []string{"origin"}; len(gotArgs) != 1 || gotArgs[0] != want[0] {
+		t.Errorf("nested command args = %v, want %v", gotArgs, want)
+	}
+}
+
+// TestBareGroupCommandPrintsUsageInsteadOfPanicking exercises the group
+// command in a subprocess: the code path under test calls os.Exit(2), which
+// would otherwise tear down the test binary itself.
+func TestBareGroupCommandPrintsUsageInsteadOfPanicking(t *testing.T) {
+	if os.Getenv("CLI_TEST_BARE_GROUP_COMMAND") == "1" {
+		cs := NewCommandSet("myprog", "")
+		remote := &Command{Short: "manage remotes"}
+		cs.Register("remote", remote)
+		remote.Register("add", &Command{
+			Usage: "add <name> <url>",
+			Run:   func([]string) error { return nil },
+		})
+		cs.Run("remote", nil)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestBareGroupCommandPrintsUsageInsteadOfPanicking$")
+	cmd.Env = append(os.Environ(), "CLI_TEST_BARE_GROUP_COMMAND=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("subprocess did not run: %v (stderr: %s)", err, stderr.String())
+	}
+	got := stderr.String()
+	if strings.Contains(got, "panic:") {
+		t.Errorf("invoking a bare group command panicked; stderr:\n%s", got)
+	}
+	for _, want := range []string{"usage: myprog", "Subcommands:", "add"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stderr = %q, want it to contain %q", got, want)
+		}
+	}
+}
@@ -6,74 +6,124 @@ package cli
 import (
 	"flag"
 	"fmt"
-	"os"
 	"reflect"
+	"strings"
 )
 
 // PrintUsage prints the usage text for a command to standard error. If
 // no command is given, the list of available commands is printed instead.
 func (cs *CommandSet) PrintUsage(name string) {
-	if cmd, ok := cs.cmds[name]; ok {
-		cs.printUsageCmd(cmd)
+	if name == "" {
+		cs.printUsageList()
 		return
 	}
+	cs.PrintUsagePath([]string{name})
+}
+
+// PrintUsagePath prints the usage text for a (possibly nested) command
+// reached by following path through successive Subcommands, e.g.
+// []string{"remote", "add"}. An empty path prints the list of available
+// commands, as PrintUsage("") does.
+func (cs *CommandSet) PrintUsagePath(path []string) {
+	if len(path) == 0 {
+		cs.printUsageList()
+		return
+	}
+
+	cmd, ok := cs.cmds[path[0]]
+	if !ok {
+		cs.unknownCommand(path[0])
+		return
+	}
+
+	if len(path) > 1 && cmd.Subcommands != nil {
+		cmd.Subcommands.PrintUsagePath(path[1:])
+		return
+	}
+
+	cs.printUsageCmd(cmd)
+}
 
-	eprintf("usage: %s <command> [arguments]\n\n", cs.name())
+func (cs *CommandSet) printUsageList() {
+	cs.eprintf("usage: %s <command> [arguments]\n\n", cs.name())
 
 	if names := cs.actions(); len(names) > 0 {
-		eprintln("Available commands:")
+		cs.eprintln("Available commands:")
 		nameWidth := maxLen(names)
 		for _, name := range names {
-			eprintf("    %-*s   %s\n", nameWidth, name, cs.cmds[name].Short)
+			cs.eprintf("    %s%s   %s\n", name, pad(nameWidth-stringWidth(name)), cs.cmds[name].Short)
 		}
-		eprintf("\nUse '%s help <command>' for more information on a specific command.\n", cs.name())
+		cs.eprintf("\nUse '%s help <command>' for more information on a specific command.\n", cs.name())
 	}
 
-	eprintln()
+	cs.eprintln()
 }
 
 func maxLen(ary []string) int {
 	max := 0
 	for _, s := range ary {
-		if len(s) > max {
-			max = len(s)
+		if w := stringWidth(s); w > max {
+			max = w
 		}
 	}
 	return max
 }
 
+// pad returns a string of n spaces, or the empty string if n <= 0.
+func pad(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", n)
+}
+
 // cmd.Usage(programName)
 func (cs *CommandSet) printUsageCmd(cmd *Command) {
-	eprintf("usage: %s %s\n\n", cs.name(), cmd.Usage)
-	eprintf("Arguments:\n")
-	columnize(&cmd.Flags)
+	cs.eprintf("usage: %s %s\n\n", cs.name(), cmd.Usage)
+	cs.eprintf("Arguments:\n")
+	cs.columnize(cmd)
+
+	if cmd.Subcommands != nil {
+		if names := cmd.Subcommands.actions(); len(names) > 0 {
+			cs.eprintf("\nSubcommands:\n")
+			nameWidth := maxLen(names)
+			for _, name := range names {
+				cs.eprintf("    %s%s   %s\n", name, pad(nameWidth-stringWidth(name)), cmd.Subcommands.cmds[name].Short)
+			}
+		}
+	}
+
 	if cmd.Synopsis != "" {
-		eprintf("\n%s\n", cmd.Synopsis)
+		cs.eprintf("\n%s\n", cmd.Synopsis)
 	}
 }
 
 // columnize aligns a set of flags into two columns. One for the flag
 // plus its default value, and one for the usage text. The columns are
 // printed to standard output with a left margin of 3 spaces.
-func columnize(flags *flag.FlagSet) {
+//
+// Column widths are measured in terminal display width rather than bytes,
+// so multi-byte and East Asian wide characters in flag names, defaults, or
+// usage text still line up.
+func (cs *CommandSet) columnize(cmd *Command) {
 	var rows [][2]string
-	flags.VisitAll(func(f *flag.Flag) {
-		rows = append(rows, [2]string{formatFlag(f), f.Usage})
+	cmd.flags.VisitAll(func(f *flag.Flag) {
+		rows = append(rows, [2]string{formatFlag(cmd, f), f.Usage})
 	})
 
 	flagWidth := 0
 	for _, row := range rows {
-		if len(row[0]) > flagWidth {
-			flagWidth = len(row[0])
+		if w := stringWidth(row[0]); w > flagWidth {
+			flagWidth = w
 		}
 	}
 
 	for _, row := range rows {
-		eprintf("   %-*s   %s\n", flagWidth, row[0], row[1])
+		cs.eprintf("   %s%s   %s\n", row[0], pad(flagWidth-stringWidth(row[0])), row[1])
 	}
 }
 
-func formatFlag(f *flag.Flag) string {
+func formatFlag(cmd *Command, f *flag.Flag) string {
 	leading := "-"
 	if len(f.Name) > 1 {
 		leading = "--"
@@ -84,7 +134,22 @@ func formatFlag(f *flag.Flag) string {
 		format = "%s%s=%q"
 	}
 
-	return fmt.Sprintf(format, leading, f.Name, f.DefValue)
+	s := fmt.Sprintf(format, leading, f.Name, f.DefValue)
+
+	if envVar, ok := cmd.envVars[f.Name]; ok {
+		var hints []string
+		if envVar != "" {
+			hints = append(hints, "$"+envVar)
+		}
+		if key := cmd.configKey(f.Name); key != "" {
+			hints = append(hints, "cfg: "+key)
+		}
+		if len(hints) > 0 {
+			s += "   [" + strings.Join(hints, ", ") + "]"
+		}
+	}
+
+	return s
 }
 
 // shouldQuoteValue determines whether a Flag's default value should
@@ -94,10 +159,10 @@ func shouldQuoteValue(f *flag.Flag) bool {
 	return typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.String
 }
 
-func eprintf(format string, a ...interface{}) (int, error) {
-	return fmt.Fprintf(os.Stderr, format, a...)
+func (cs *CommandSet) eprintf(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(cs.err(), format, a...)
 }
 
-func eprintln(a ...interface{}) (int, error) {
-	return fmt.Fprintln(os.Stderr, a...)
+func (cs *CommandSet) eprintln(a ...interface{}) (int, error) {
+	return fmt.Fprintln(cs.err(), a...)
 }
@@ -0,0 +1,181 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completeCommandName is the name of the hidden command that emitted shell
+// completion scripts call back into the binary with to resolve dynamic
+// completions (filenames, remote resources, etc).
+const completeCommandName = "__complete"
+
+// ArgCompleter returns candidate completions for the word currently being
+// typed, given the already-parsed non-flag arguments that precede it. It is
+// invoked by the script generated by GenCompletion via the hidden
+// "__complete" command.
+type ArgCompleter func(prev []string, cur string) []string
+
+// EnableCompletion registers the built-in "completion" and "__complete"
+// commands on cs. Call it once during setup. The generated scripts list no
+// command names themselves; they call back into "__complete", so
+// candidates always reflect the commands registered at run time.
+func (cs *CommandSet) EnableCompletion() {
+	cs.Register("completion", &Command{
+		Usage: "completion <bash|zsh|fish|powershell>",
+		Short: "generate shell completion script",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("completion: expected exactly one shell name")
+			}
+			return cs.GenCompletion(args[0], cs.out())
+		},
+	})
+
+	cs.Register(completeCommandName, &Command{
+		Usage: completeCommandName + " <command> -- <args...>",
+		Short: "print completion candidates (used by generated scripts)",
+		Run: func(args []string) error {
+			cs.printCompletions(cs.out(), args)
+			return nil
+		},
+	})
+}
+
+// GenCompletion writes a shell completion script for the given shell to w.
+// shell must be one of "bash", "zsh", "fish", or "powershell".
+func (cs *CommandSet) GenCompletion(shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return cs.genBashCompletion(w)
+	case "zsh":
+		return cs.genZshCompletion(w)
+	case "fish":
+		return cs.genFishCompletion(w)
+	case "powershell":
+		return cs.genPowerShellCompletion(w)
+	default:
+		return fmt.Errorf("cli: unsupported shell %q", shell)
+	}
+}
+
+func (cs *CommandSet) genBashCompletion(w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, "# bash completion for %s\n", prog)
+	fmt.Fprintf(w, "_%s_complete() {\n", prog)
+	fmt.Fprintf(w, "    local cur prev words\n")
+	fmt.Fprintf(w, "    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    words=(\"%s\" \"${COMP_WORDS[@]:1:COMP_CWORD-1}\")\n", completeCommandName)
+	fmt.Fprintf(w, "    COMPREPLY=( $(%s \"${words[@]}\" -- \"$cur\") )\n", prog)
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F _%s_complete %s\n", prog, prog)
+	return nil
+}
+
+func (cs *CommandSet) genZshCompletion(w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, "#compdef %s\n\n", prog)
+	fmt.Fprintf(w, "_%s() {\n", prog)
+	fmt.Fprintf(w, "    local cur\n")
+	fmt.Fprintf(w, "    cur=\"${words[CURRENT]}\"\n")
+	fmt.Fprintf(w, "    local -a completions\n")
+	fmt.Fprintf(w, "    completions=(\"${(@f)$(%s %s \"${words[@]:1:CURRENT-2}\" -- \"$cur\")}\")\n", prog, completeCommandName)
+	fmt.Fprintf(w, "    _describe 'command' completions\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "compdef _%s %s\n", prog, prog)
+	return nil
+}
+
+func (cs *CommandSet) genFishCompletion(w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, "function __%s_complete\n", prog)
+	fmt.Fprintf(w, "    set -l tokens (commandline -opc)\n")
+	fmt.Fprintf(w, "    set -l cur (commandline -ct)\n")
+	fmt.Fprintf(w, "    %s %s $tokens[2..-1] -- $cur\n", prog, completeCommandName)
+	fmt.Fprintf(w, "end\n")
+	fmt.Fprintf(w, "complete -c %s -f -a '(__%s_complete)'\n", prog, prog)
+	return nil
+}
+
+func (cs *CommandSet) genPowerShellCompletion(w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", prog)
+	fmt.Fprintf(w, "    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "    $prev = $commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() }\n")
+	fmt.Fprintf(w, "    & %s %s $prev -- $wordToComplete | ForEach-Object {\n", prog, completeCommandName)
+	fmt.Fprintf(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+// printCompletions writes newline-separated completion candidates for the
+// word being typed, given args in the form documented on the
+// completeCommandName command's Usage: the already-parsed command path and
+// prior arguments, then a literal "--", then the partial word to complete.
+// The "--" is located explicitly rather than assumed to be the next-to-last
+// argument, since flag.Parse only strips a leading "--" and otherwise
+// leaves it in place. If no "--" is left, it was the leading one: there
+// are no prior words and args[0], if any, is the partial word.
+func (cs *CommandSet) printCompletions(w io.Writer, args []string) {
+	var prev []string
+	cur := ""
+	if len(args) > 0 {
+		cur = args[0]
+	}
+	for i, a := range args {
+		if a == "--" {
+			prev, cur = args[:i], ""
+			if i+1 < len(args) {
+				cur = args[i+1]
+			}
+			break
+		}
+	}
+
+	cs.completeAt(w, prev, cur)
+}
+
+// completeAt writes newline-separated completion candidates for cur, given
+// prev, the already-typed words naming a (possibly nested) command. It
+// walks into cmd.Subcommands the same way run and PrintUsagePath do, so
+// completion works at any nesting depth, not just for top-level commands.
+func (cs *CommandSet) completeAt(w io.Writer, prev []string, cur string) {
+	if len(prev) == 0 {
+		for _, name := range cs.actions() {
+			if !isHiddenCommand(name) && strings.HasPrefix(name, cur) {
+				fmt.Fprintln(w, name)
+			}
+		}
+		return
+	}
+
+	cmd, ok := cs.cmds[prev[0]]
+	if !ok {
+		return
+	}
+
+	if cmd.Subcommands != nil {
+		cmd.Subcommands.completeAt(w, prev[1:], cur)
+		return
+	}
+
+	for _, candidate := range cmd.ValidArgs {
+		if strings.HasPrefix(candidate, cur) {
+			fmt.Fprintln(w, candidate)
+		}
+	}
+	if cmd.ArgCompleter != nil {
+		for _, candidate := range cmd.ArgCompleter(prev[1:], cur) {
+			fmt.Fprintln(w, candidate)
+		}
+	}
+}
+
+func isHiddenCommand(name string) bool {
+	return name == completeCommandName
+}
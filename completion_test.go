@@ -0,0 +1,99 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintCompletionsSplitsOnSentinel(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	var gotPrev []string
+	var gotCur string
+	cs.Register("remote", &Command{
+		Run: func([]string) error { return nil },
+		ArgCompleter: func(prev []string, cur string) []string {
+			gotPrev, gotCur = prev, cur
+			return []string{"origin"}
+		},
+	})
+
+	// As generated by genBashCompletion: already-typed words, then a
+	// literal "--", then the partial word being completed. The "--"
+	// survives flag.Parse here because it isn't the first argument, so
+	// printCompletions must find it itself rather than assume cur is the
+	// last element of args.
+	var buf bytes.Buffer
+	cs.printCompletions(&buf, []string{"remote", "--", "or"})
+
+	if gotCur != "or" {
+		t.Errorf("cur = %q, want %q", gotCur, "or")
+	}
+	if len(gotPrev) != 0 {
+		t.Errorf("prev = %v, want []", gotPrev)
+	}
+	if got, want := buf.String(), "origin\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintCompletionsTopLevel(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	cs.Register("remote", &Command{Run: func([]string) error { return nil }})
+	cs.Register("branch", &Command{Run: func([]string) error { return nil }})
+
+	var buf bytes.Buffer
+	cs.printCompletions(&buf, []string{"--", "re"})
+
+	if got, want := buf.String(), "remote\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintCompletionsOffersNestedSubcommandNames(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	remote := &Command{Short: "manage remotes"}
+	cs.Register("remote", remote)
+	remote.Register("add", &Command{Run: func([]string) error { return nil }})
+	remote.Register("rm", &Command{Run: func([]string) error { return nil }})
+
+	var buf bytes.Buffer
+	cs.printCompletions(&buf, []string{"remote", "--", "a"})
+
+	if got, want := buf.String(), "add\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintCompletionsReachesNestedArgCompleter(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	remote := &Command{Short: "manage remotes"}
+	cs.Register("remote", remote)
+
+	var gotPrev []string
+	var gotCur string
+	remote.Register("add", &Command{
+		Run: func([]string) error { return nil },
+		ArgCompleter: func(prev []string, cur string) []string {
+			gotPrev, gotCur = prev, cur
+			return []string{"origin"}
+		},
+	})
+
+	// Reproduces "prog __complete remote add -- or": two levels of
+	// Subcommands must be walked before reaching add's ArgCompleter.
+	var buf bytes.Buffer
+	cs.printCompletions(&buf, []string{"remote", "add", "--", "or"})
+
+	if gotCur != "or" {
+		t.Errorf("cur = %q, want %q", gotCur, "or")
+	}
+	if len(gotPrev) != 0 {
+		t.Errorf("prev = %v, want []", gotPrev)
+	}
+	if got, want := buf.String(), "origin\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
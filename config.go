@@ -0,0 +1,204 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A ConfigFormat selects the decoder CommandSet.SetConfigFile uses to
+// parse a config file.
+type ConfigFormat int
+
+const (
+	ConfigJSON ConfigFormat = iota
+	ConfigTOML
+	ConfigYAML
+)
+
+// A ConfigDecoder parses raw config file bytes into a tree of nested maps,
+// the same shape encoding/json produces when unmarshaling into a
+// map[string]interface{}.
+type ConfigDecoder func(data []byte) (map[string]interface{}, error)
+
+// configDecoders holds the decoder used for each ConfigFormat. The TOML
+// and YAML entries are minimal, dependency-free decoders covering flat or
+// one-level-nested "key = value" / "key: value" files, which is enough for
+// binding flags of top-level commands. They cannot express the deeper keys
+// of nested commands, such as "remote.add.timeout"; register a
+// full-featured library with RegisterConfigDecoder for anything more.
+var configDecoders = map[ConfigFormat]ConfigDecoder{
+	ConfigJSON: decodeJSONConfig,
+	ConfigTOML: decodeTOMLConfig,
+	ConfigYAML: decodeYAMLConfig,
+}
+
+// RegisterConfigDecoder overrides the decoder used for format.
+func RegisterConfigDecoder(format ConfigFormat, decode ConfigDecoder) {
+	configDecoders[format] = decode
+}
+
+// SetConfigFile loads path with the decoder registered for format and
+// makes its values available as a fallback for flags defined with
+// StringEnv and its siblings. The resolution order applied in Run is:
+// explicit command-line flag, then bound environment variable, then a
+// "<command>.<flag>" key in the config file, then the flag's own default.
+func (cs *CommandSet) SetConfigFile(path string, format ConfigFormat) error {
+	decode, ok := configDecoders[format]
+	if !ok {
+		return fmt.Errorf("cli: no decoder registered for config format %d", format)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tree, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("cli: parsing %s: %w", path, err)
+	}
+
+	cs.config = tree
+	return nil
+}
+
+// configValue looks up a dotted key, e.g. "export.timeout", in the loaded
+// config tree. A CommandSet nested under a Command via Command.Register
+// never has its own config tree (SetConfigFile is only ever called on the
+// root CommandSet), so a miss falls back to cs.parent, walking up to the
+// root the same way name() does.
+func (cs *CommandSet) configValue(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+
+	if cs.config != nil {
+		var node interface{} = cs.config
+		found := true
+		for _, part := range strings.Split(key, ".") {
+			m, ok := node.(map[string]interface{})
+			if !ok {
+				found = false
+				break
+			}
+			if node, ok = m[part]; !ok {
+				found = false
+				break
+			}
+		}
+		if found && node != nil {
+			if s, ok := node.(string); ok {
+				return s, true
+			}
+			return fmt.Sprint(node), true
+		}
+	}
+
+	if cs.parent != nil {
+		return cs.parent.configValue(key)
+	}
+	return "", false
+}
+
+func decodeJSONConfig(data []byte) (map[string]interface{}, error) {
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// decodeTOMLConfig implements a minimal subset of TOML: "key = value"
+// assignments and "[section]" headers, one level deep. It does not
+// support arrays, inline tables, or multi-line strings.
+func decodeTOMLConfig(data []byte) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	section := root
+
+	for i, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			sub := map[string]interface{}{}
+			root[name] = sub
+			section = sub
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("toml: line %d: expected \"key = value\"", i+1)
+		}
+		section[strings.TrimSpace(key)] = parseScalar(strings.TrimSpace(value))
+	}
+
+	return root, nil
+}
+
+// decodeYAMLConfig implements a minimal subset of YAML: two-space indented
+// "key: value" mappings, one level deep. It does not support lists, flow
+// style, or multi-document files.
+func decodeYAMLConfig(data []byte) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	var section map[string]interface{}
+
+	for i, rawLine := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(rawLine)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected \"key: value\"", i+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if !strings.HasPrefix(rawLine, " ") {
+			if value == "" {
+				section = map[string]interface{}{}
+				root[key] = section
+				continue
+			}
+			section = nil
+			root[key] = parseScalar(value)
+			continue
+		}
+
+		if section == nil {
+			return nil, fmt.Errorf("yaml: line %d: indented mapping without a parent key", i+1)
+		}
+		section[key] = parseScalar(value)
+	}
+
+	return root, nil
+}
+
+// parseScalar converts a bare TOML/YAML scalar to the Go type it most
+// resembles, falling back to the original string.
+func parseScalar(s string) interface{} {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
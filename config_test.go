@@ -0,0 +1,130 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFormatFlagOmitsEmptyEnvVarHint(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	export := &Command{Run: func([]string) error { return nil }}
+	export.StringEnv("timeout", "5", "", "request timeout")
+	cs.Register("export", export)
+
+	var f *flag.Flag
+	export.flags.VisitAll(func(vf *flag.Flag) { f = vf })
+
+	got := formatFlag(export, f)
+	if strings.Contains(got, "$") {
+		t.Errorf("formatFlag(%q) = %q, want no \"$\" hint for an empty envVar", f.Name, got)
+	}
+	if want := "cfg: export.timeout"; !strings.Contains(got, want) {
+		t.Errorf("formatFlag(%q) = %q, want it to still contain %q", f.Name, got, want)
+	}
+}
+
+func TestConfigBindingCascadesToNestedCommands(t *testing.T) {
+	path := writeTestConfig(t, `{"remote":{"add":{"timeout":"30"}}}`)
+
+	cs := NewCommandSet("prog", "")
+	if err := cs.SetConfigFile(path, ConfigJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &Command{}
+	cs.Register("remote", remote)
+
+	var timeout *string
+	add := &Command{Run: func([]string) error { return nil }}
+	timeout = add.StringEnv("timeout", "5", "", "request timeout")
+	remote.Register("add", add)
+
+	if err := cs.Run("remote", []string{"add"}); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if *timeout != "30" {
+		t.Errorf("timeout = %q, want %q (config value should reach a command nested under Subcommands)", *timeout, "30")
+	}
+}
+
+func TestConfigKeyDoesNotCollideAcrossParents(t *testing.T) {
+	path := writeTestConfig(t, `{"remote":{"add":{"timeout":"30"}},"branch":{"add":{"timeout":"60"}}}`)
+
+	cs := NewCommandSet("prog", "")
+	if err := cs.SetConfigFile(path, ConfigJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	remote, branch := &Command{}, &Command{}
+	cs.Register("remote", remote)
+	cs.Register("branch", branch)
+
+	remoteAdd := &Command{Run: func([]string) error { return nil }}
+	remoteTimeout := remoteAdd.StringEnv("timeout", "5", "", "")
+	remote.Register("add", remoteAdd)
+
+	branchAdd := &Command{Run: func([]string) error { return nil }}
+	branchTimeout := branchAdd.StringEnv("timeout", "5", "", "")
+	branch.Register("add", branchAdd)
+
+	if err := cs.Run("remote", []string{"add"}); err != nil {
+		t.Fatalf("Run(remote add) returned %v", err)
+	}
+	if err := cs.Run("branch", []string{"add"}); err != nil {
+		t.Fatalf("Run(branch add) returned %v", err)
+	}
+
+	if *remoteTimeout != "30" {
+		t.Errorf("remote add timeout = %q, want %q", *remoteTimeout, "30")
+	}
+	if *branchTimeout != "60" {
+		t.Errorf("branch add timeout = %q, want %q (must not collide with remote add's config key)", *branchTimeout, "60")
+	}
+}
+
+func TestResolveBindingsOrder(t *testing.T) {
+	path := writeTestConfig(t, `{"export":{"timeout":"30"}}`)
+
+	cs := NewCommandSet("prog", "")
+	if err := cs.SetConfigFile(path, ConfigJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	export := &Command{Run: func([]string) error { return nil }}
+	timeout := export.StringEnv("timeout", "5", "PROG_TIMEOUT", "")
+	cs.Register("export", export)
+
+	os.Setenv("PROG_TIMEOUT", "15")
+	defer os.Unsetenv("PROG_TIMEOUT")
+
+	// Env var beats config file when both are present.
+	if err := cs.Run("export", nil); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if *timeout != "15" {
+		t.Errorf("timeout = %q, want %q (env var should win over config file)", *timeout, "15")
+	}
+
+	// An explicit flag beats both.
+	if err := cs.Run("export", []string{"-timeout=1"}); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if *timeout != "1" {
+		t.Errorf("timeout = %q, want %q (explicit flag should win over everything)", *timeout, "1")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// A Context is passed to a Command's RunCtx function. It embeds a
+// context.Context that's cancelled on SIGINT/SIGTERM (see CommandSet.Run),
+// carries the command's I/O streams, and lets a nested leaf command look
+// up flags declared on an enclosing Command, such as a --verbose flag
+// shared by a whole Subcommands tree.
+type Context struct {
+	context.Context
+
+	parent *Context
+	name   string
+	flags  *flag.FlagSet
+	args   []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func newContext(ctx context.Context, parent *Context, name string, flags *flag.FlagSet) *Context {
+	return &Context{Context: ctx, parent: parent, name: name, flags: flags}
+}
+
+// Path returns the resolved command path, e.g. ["remote", "add"] for
+// "myprog remote add origin".
+func (c *Context) Path() []string {
+	if c.parent == nil {
+		return []string{c.name}
+	}
+	return append(c.parent.Path(), c.name)
+}
+
+// Args returns the arguments remaining after flag parsing for this command.
+func (c *Context) Args() []string {
+	return c.args
+}
+
+// Flag looks up a flag by name, first on this command and then on each
+// enclosing parent in turn.
+func (c *Context) Flag(name string) *flag.Flag {
+	for ctx := c; ctx != nil; ctx = ctx.parent {
+		if f := ctx.flags.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Lookup returns the current value of the named flag as T, searching ctx
+// and its parents as Context.Flag does. It panics if no such flag exists or
+// its value isn't assignable to T; use it only for flags declared on the
+// command or one of its enclosing commands.
+func Lookup[T any](ctx *Context, name string) T {
+	f := ctx.Flag(name)
+	if f == nil {
+		panic(fmt.Sprintf("cli: no such flag %q", name))
+	}
+	v, ok := f.Value.(flag.Getter).Get().(T)
+	if !ok {
+		panic(fmt.Sprintf("cli: flag %q is not assignable to %T", name, v))
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"bytes"
+	gocontext "context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunCtxCancellation(t *testing.T) {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+
+	cs := NewCommandSet("prog", "")
+	var sawDone bool
+	cs.Register("wait", &Command{
+		RunCtx: func(c *Context) error {
+			select {
+			case <-c.Done():
+				sawDone = true
+			default:
+			}
+			return c.Err()
+		},
+	})
+
+	if err := cs.run(ctx, "wait", nil, nil, nil); err != gocontext.Canceled {
+		t.Errorf("run returned %v, want context.Canceled", err)
+	}
+	if !sawDone {
+		t.Error("RunCtx command did not observe an already-cancelled context")
+	}
+}
+
+func TestRunCtxIORedirection(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	var stdout, stderr bytes.Buffer
+	cs.SetOutput(&stdout, &stderr)
+
+	var gotStdout, gotStderr interface{}
+	cs.Register("echo", &Command{
+		RunCtx: func(c *Context) error {
+			gotStdout, gotStderr = c.Stdout, c.Stderr
+			return nil
+		},
+	})
+
+	if err := cs.run(gocontext.Background(), "echo", nil, nil, nil); err != nil {
+		t.Fatalf("run returned %v", err)
+	}
+	if gotStdout != io.Writer(&stdout) {
+		t.Error("Context.Stdout was not the CommandSet's redirected stdout")
+	}
+	if gotStderr != io.Writer(&stderr) {
+		t.Error("Context.Stderr was not the CommandSet's redirected stderr")
+	}
+}
+
+func TestContextPathAndFlag(t *testing.T) {
+	cs := NewCommandSet("prog", "")
+	remote := &Command{}
+	verbose := remote.Bool("verbose", false, "be verbose")
+	cs.Register("remote", remote)
+
+	var path []string
+	var sawVerbose *bool
+	remote.Register("add", &Command{
+		RunCtx: func(c *Context) error {
+			path = c.Path()
+			if f := c.Flag("verbose"); f != nil {
+				sawVerbose = verbose
+			}
+			return nil
+		},
+	})
+	remote.Persistent = true
+
+	if err := cs.Run("remote", []string{"-verbose", "add", "origin"}); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if want := "remote add"; strings.Join(path, " ") != want {
+		t.Errorf("Path() = %v, want %q", path, want)
+	}
+	if sawVerbose == nil || !*sawVerbose {
+		t.Error("nested command did not see the parent's persistent --verbose flag")
+	}
+}
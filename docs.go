@@ -0,0 +1,245 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// PrintUsageFormat writes the usage text for name (or, if name is "", the
+// command listing) to w in the given format: "text" behaves like
+// PrintUsage/PrintUsagePath, "json" emits a machine-readable command tree,
+// and "man" emits a groff/troff man page. The "json" format ignores name
+// and always emits the tree of every command in cs.
+func (cs *CommandSet) PrintUsageFormat(name, format string, w io.Writer) error {
+	switch format {
+	case "", "text":
+		var path []string
+		if name != "" {
+			path = []string{name}
+		}
+		cs.withWriter(w).PrintUsagePath(path)
+		return nil
+	case "json":
+		return cs.writeUsageJSON(w)
+	case "man":
+		return cs.writeManPage(name, w)
+	default:
+		return fmt.Errorf("cli: unsupported help format %q", format)
+	}
+}
+
+// withWriter returns a shallow copy of cs with both stdout and stderr
+// redirected to w, reusing the same underlying commands. It lets the
+// "text" format in PrintUsageFormat reuse PrintUsage/printUsageCmd's
+// rendering logic without duplicating it.
+func (cs *CommandSet) withWriter(w io.Writer) *CommandSet {
+	clone := *cs
+	clone.stdout = w
+	clone.stderr = w
+	return &clone
+}
+
+// jsonFlag is the machine-readable description of a single flag, as
+// emitted by PrintUsageFormat's "json" format.
+type jsonFlag struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+	EnvVar  string `json:"envvar,omitempty"`
+}
+
+// jsonCommand is the machine-readable description of a single command, as
+// emitted by PrintUsageFormat's "json" format.
+type jsonCommand struct {
+	Name        string          `json:"name"`
+	Short       string          `json:"short"`
+	Synopsis    string          `json:"synopsis,omitempty"`
+	Usage       string          `json:"usage"`
+	Flags       []jsonFlag      `json:"flags"`
+	Subcommands *jsonCommandSet `json:"subcommands,omitempty"`
+}
+
+// jsonCommandSet is the root of the schema emitted by PrintUsageFormat's
+// "json" format: {name, desc, commands:[...]}.
+type jsonCommandSet struct {
+	Name     string        `json:"name"`
+	Desc     string        `json:"desc"`
+	Commands []jsonCommand `json:"commands"`
+}
+
+func (cs *CommandSet) usageTree() jsonCommandSet {
+	tree := jsonCommandSet{Name: cs.name(), Desc: cs.Desc}
+	for _, name := range cs.actions() {
+		cmd := cs.cmds[name]
+		jc := jsonCommand{
+			Name:     name,
+			Short:    cmd.Short,
+			Synopsis: cmd.Synopsis,
+			Usage:    cmd.Usage,
+			Flags:    []jsonFlag{},
+		}
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			jc.Flags = append(jc.Flags, jsonFlag{
+				Name:    f.Name,
+				Type:    reflect.TypeOf(f.Value).Elem().Name(),
+				Default: f.DefValue,
+				Usage:   f.Usage,
+				EnvVar:  cmd.envVars[f.Name],
+			})
+		})
+		if cmd.Subcommands != nil {
+			sub := cmd.Subcommands.usageTree()
+			jc.Subcommands = &sub
+		}
+		tree.Commands = append(tree.Commands, jc)
+	}
+	return tree
+}
+
+func (cs *CommandSet) writeUsageJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cs.usageTree())
+}
+
+// writeManPage writes a single groff/troff man page: the root page
+// describing cs itself when name is "", or the page for the named command.
+func (cs *CommandSet) writeManPage(name string, w io.Writer) error {
+	if name == "" {
+		return cs.writeManRootPage(w)
+	}
+	cmd, ok := cs.cmds[name]
+	if !ok {
+		return fmt.Errorf("cli: unknown command %q", name)
+	}
+	return cs.writeManCmdPage(cmd, w)
+}
+
+func (cs *CommandSet) writeManRootPage(w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, ".TH %s 1\n", strings.ToUpper(prog))
+	fmt.Fprintf(w, ".SH NAME\n%s", prog)
+	if cs.Desc != "" {
+		fmt.Fprintf(w, " \\- %s", cs.Desc)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n<command> [arguments]\n", prog)
+	if cs.Desc != "" {
+		fmt.Fprintf(w, ".SH DESCRIPTION\n%s\n", cs.Desc)
+	}
+	if names := cs.actions(); len(names) > 0 {
+		fmt.Fprintf(w, ".SH COMMANDS\n")
+		for _, name := range names {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", name, cs.cmds[name].Short)
+		}
+	}
+	return nil
+}
+
+func (cs *CommandSet) writeManCmdPage(cmd *Command, w io.Writer) error {
+	prog := cs.name()
+	fmt.Fprintf(w, ".TH %s 1\n", strings.ToUpper(prog+"-"+cmd.name))
+	fmt.Fprintf(w, ".SH NAME\n%s %s \\- %s\n", prog, cmd.name, cmd.Short)
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n%s\n", prog, cmd.Usage)
+	if cmd.Synopsis != "" {
+		fmt.Fprintf(w, ".SH DESCRIPTION\n%s\n", cmd.Synopsis)
+	}
+
+	var hasFlags bool
+	cmd.flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(w, ".SH OPTIONS\n")
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", formatFlag(cmd, f), f.Usage)
+		})
+	}
+
+	if cmd.Subcommands != nil {
+		if names := cmd.Subcommands.actions(); len(names) > 0 {
+			fmt.Fprintf(w, ".SH COMMANDS\n")
+			for _, name := range names {
+				fmt.Fprintf(w, ".TP\n.B %s\n%s\n", name, cmd.Subcommands.cmds[name].Short)
+			}
+		}
+	}
+	return nil
+}
+
+// EnableDocs registers a built-in "docs" command that writes a text, JSON,
+// or man page for cs and every command in it to a directory in one go, for
+// release pipelines that install a goreleaser-style doc/man layout.
+func (cs *CommandSet) EnableDocs() {
+	cmd := &Command{
+		Usage: "docs [-format text|json|man] [-out dir]",
+		Short: "generate documentation pages",
+	}
+	format := cmd.String("format", "man", "output format: text, json, or man")
+	out := cmd.String("out", ".", "directory to write generated pages to")
+	cmd.Run = func(args []string) error {
+		return cs.writeDocs(*format, *out)
+	}
+	cs.Register("docs", cmd)
+}
+
+func (cs *CommandSet) writeDocs(format, outDir string) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	prog := cs.name()
+
+	if format == "json" {
+		return writeDocFile(filepath.Join(outDir, prog+".json"), func(w io.Writer) error {
+			return cs.PrintUsageFormat("", "json", w)
+		})
+	}
+
+	ext, ok := map[string]string{"man": "1", "text": "txt"}[format]
+	if !ok {
+		return fmt.Errorf("cli: unsupported docs format %q", format)
+	}
+
+	if err := writeDocFile(filepath.Join(outDir, prog+"."+ext), func(w io.Writer) error {
+		return cs.PrintUsageFormat("", format, w)
+	}); err != nil {
+		return err
+	}
+	return cs.writeCmdDocs(prog, format, ext, outDir)
+}
+
+func (cs *CommandSet) writeCmdDocs(pagePrefix, format, ext, outDir string) error {
+	for _, name := range cs.actions() {
+		cmd := cs.cmds[name]
+		page := pagePrefix + "-" + name
+		if err := writeDocFile(filepath.Join(outDir, page+"."+ext), func(w io.Writer) error {
+			return cs.PrintUsageFormat(name, format, w)
+		}); err != nil {
+			return err
+		}
+		if cmd.Subcommands != nil {
+			if err := cmd.Subcommands.writeCmdDocs(page, format, ext, outDir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeDocFile(path string, fn func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(f)
+}
@@ -0,0 +1,138 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newDocsTestCommandSet() *CommandSet {
+	cs := NewCommandSet("myprog", "does things")
+	export := &Command{
+		Usage: "export [-v]",
+		Short: "export some data",
+		Run:   func([]string) error { return nil },
+	}
+	export.Bool("v", false, "be verbose")
+	cs.Register("export", export)
+	return cs
+}
+
+func TestPrintUsageFormatJSON(t *testing.T) {
+	cs := newDocsTestCommandSet()
+
+	var buf bytes.Buffer
+	if err := cs.PrintUsageFormat("", "json", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var tree jsonCommandSet
+	if err := json.Unmarshal(buf.Bytes(), &tree); err != nil {
+		t.Fatalf("output did not parse as JSON: %v\n%s", err, buf.String())
+	}
+	if tree.Name != "myprog" {
+		t.Errorf("Name = %q, want %q", tree.Name, "myprog")
+	}
+	if len(tree.Commands) != 1 || tree.Commands[0].Name != "export" {
+		t.Errorf("Commands = %+v, want a single \"export\" entry", tree.Commands)
+	}
+	if len(tree.Commands[0].Flags) != 1 || tree.Commands[0].Flags[0].Name != "v" {
+		t.Errorf("Commands[0].Flags = %+v, want a single \"v\" flag", tree.Commands[0].Flags)
+	}
+}
+
+func TestPrintUsageFormatMan(t *testing.T) {
+	cs := newDocsTestCommandSet()
+
+	var buf bytes.Buffer
+	if err := cs.PrintUsageFormat("", "man", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{".TH MYPROG 1", ".SH NAME\nmyprog", ".SH COMMANDS"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("man page = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestPrintUsageFormatUnsupported(t *testing.T) {
+	cs := newDocsTestCommandSet()
+	if err := cs.PrintUsageFormat("", "xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestWriteDocsWritesPageTree(t *testing.T) {
+	cs := newDocsTestCommandSet()
+	remote := &Command{Short: "manage remotes"}
+	cs.Register("remote", remote)
+	remote.Register("add", &Command{
+		Usage: "add <name> <url>",
+		Short: "add a remote",
+		Run:   func([]string) error { return nil },
+	})
+
+	outDir := t.TempDir()
+	if err := cs.writeDocs("man", outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := []string{
+		"myprog.1",
+		"myprog-export.1",
+		"myprog-remote.1",
+		"myprog-remote-add.1",
+	}
+	for _, name := range wantFiles {
+		path := filepath.Join(outDir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+			continue
+		}
+		if len(data) == 0 {
+			t.Errorf("%s was written empty", name)
+		}
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(wantFiles) {
+		t.Errorf("wrote %d files, want exactly %v", len(entries), wantFiles)
+	}
+}
+
+func TestWriteDocsCreatesOutputDirectory(t *testing.T) {
+	cs := newDocsTestCommandSet()
+	outDir := filepath.Join(t.TempDir(), "nested", "docs")
+
+	if err := cs.writeDocs("json", outDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "myprog.json")); err != nil {
+		t.Errorf("writeDocs did not create %s: %v", outDir, err)
+	}
+}
+
+func TestEnableDocsRegistersDocsCommand(t *testing.T) {
+	cs := newDocsTestCommandSet()
+	cs.EnableDocs()
+
+	outDir := t.TempDir()
+	if err := cs.Run("docs", []string{"-format", "json", "-out", outDir}); err != nil {
+		t.Fatalf("Run(docs) returned %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "myprog.json")); err != nil {
+		t.Errorf("docs command did not write %s: %v", outDir, err)
+	}
+}
@@ -4,7 +4,11 @@
 // This example demonstrates use of the cli package.
 package cli_test
 
-import "fmt"
+import (
+	"fmt"
+
+	cli "github.com/mikelaspina/go-cli"
+)
 
 // PrintUsage can be used to print a listing of available commands.
 func ExampleCommandSet_PrintUsage() {
@@ -17,7 +21,7 @@ func ExampleCommandSet_PrintUsage() {
 		},
 	}
 
-	ui := cli.New("example", "")
+	ui := cli.NewCommandSet("example", "")
 	ui.Register("version", version)
 	ui.PrintUsage("")
 }
@@ -35,7 +39,7 @@ func ExampleCommandSet_PrintUsage_command() {
 	export.Bool("-v", false, "cause export to be verbose")
 	export.String("-o", "", "output to a file")
 
-	ui := cli.New("my_program", "")
+	ui := cli.NewCommandSet("my_program", "")
 	ui.Register("export", export)
 	ui.PrintUsage("export")
 }
@@ -0,0 +1,67 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import "unicode/utf8"
+
+// zeroWidthRanges lists inclusive rune ranges that render with no visible
+// width, such as combining diacritical marks and the zero-width space.
+var zeroWidthRanges = [][2]rune{
+	{0x0300, 0x036F}, // combining diacritical marks
+	{0x0591, 0x05BD}, // Hebrew accents
+	{0x200B, 0x200B}, // zero width space
+}
+
+// wideRanges lists inclusive rune ranges for East Asian Wide and Fullwidth
+// characters, which occupy two terminal columns.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+func inRanges(r rune, ranges [][2]rune) bool {
+	for _, rg := range ranges {
+		if r >= rg[0] && r <= rg[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// runeWidth returns the terminal display width of r: 0 for zero-width
+// combining marks, 2 for East Asian Wide/Fullwidth characters, and 1 for
+// every other printable rune.
+func runeWidth(r rune) int {
+	switch {
+	case inRanges(r, zeroWidthRanges):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// stringWidth returns the terminal display width of s, summing runeWidth
+// over its decoded runes rather than counting bytes.
+func stringWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		width += runeWidth(r)
+		s = s[size:]
+	}
+	return width
+}
@@ -0,0 +1,46 @@
+// Copyright 2014 Mike LaSpina. All rights reserved.
+// See the LICENSE file for copying permission.
+
+package cli
+
+import "testing"
+
+func TestRuneWidth(t *testing.T) {
+	cases := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'-', 1},
+		{'́', 0}, // combining acute accent
+		{'​', 0}, // zero width space
+		{'日', 2}, // CJK ideograph (nichi)
+		{'本', 2}, // CJK ideograph (hon)
+		{'한', 2}, // Hangul syllable (han)
+	}
+
+	for _, c := range cases {
+		if got := runeWidth(c.r); got != c.want {
+			t.Errorf("runeWidth(%q) = %d, want %d", c.r, got, c.want)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	cases := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"export", 6},
+		{"日本語", 6},    // three CJK ideographs
+		{"café", 4},  // "cafe" plus a combining acute accent
+		{"git-日本", 8}, // mixed ASCII and wide runes
+	}
+
+	for _, c := range cases {
+		if got := stringWidth(c.s); got != c.want {
+			t.Errorf("stringWidth(%q) = %d, want %d", c.s, got, c.want)
+		}
+	}
+}